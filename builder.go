@@ -0,0 +1,145 @@
+package http_client
+
+import (
+	"context"
+	"net/http"
+)
+
+// RequestBuilder is a fluent, chainable alternative to assembling a
+// RequestOptions by hand. It still round-trips through
+// DoRequestWithOptions, so middleware, retries and debug logging all
+// apply the same way.
+type RequestBuilder struct {
+	client      *HttpClient
+	ctx         context.Context
+	method      string
+	url         string
+	urlParams   map[string]string
+	headers     map[string]string
+	payload     interface{}
+	encoder     DataEncoder
+	decoder     DataDecoder
+	result      interface{}
+	retryPolicy *RetryPolicy
+	err         error
+}
+
+// R starts a new RequestBuilder bound to ctx.
+func (c *HttpClient) R(ctx context.Context) *RequestBuilder {
+	return &RequestBuilder{client: c, ctx: ctx, method: http.MethodGet}
+}
+
+func (b *RequestBuilder) Get(url string) *RequestBuilder    { return b.method_(http.MethodGet, url) }
+func (b *RequestBuilder) Post(url string) *RequestBuilder   { return b.method_(http.MethodPost, url) }
+func (b *RequestBuilder) Put(url string) *RequestBuilder    { return b.method_(http.MethodPut, url) }
+func (b *RequestBuilder) Delete(url string) *RequestBuilder { return b.method_(http.MethodDelete, url) }
+func (b *RequestBuilder) Patch(url string) *RequestBuilder  { return b.method_(http.MethodPatch, url) }
+func (b *RequestBuilder) Head(url string) *RequestBuilder   { return b.method_(http.MethodHead, url) }
+
+func (b *RequestBuilder) method_(method, url string) *RequestBuilder {
+	b.method = method
+	b.url = url
+	return b
+}
+
+// Query adds a URL query parameter.
+func (b *RequestBuilder) Query(key, value string) *RequestBuilder {
+	if b.urlParams == nil {
+		b.urlParams = map[string]string{}
+	}
+	b.urlParams[key] = value
+	return b
+}
+
+// Header sets a request header.
+func (b *RequestBuilder) Header(key, value string) *RequestBuilder {
+	if b.headers == nil {
+		b.headers = map[string]string{}
+	}
+	b.headers[key] = value
+	return b
+}
+
+// RetryPolicy overrides the client's default retry policy for this
+// request only.
+func (b *RequestBuilder) RetryPolicy(policy *RetryPolicy) *RequestBuilder {
+	b.retryPolicy = policy
+	return b
+}
+
+// JSON sets payload to be encoded as application/json.
+func (b *RequestBuilder) JSON(payload interface{}) *RequestBuilder {
+	b.payload = payload
+	b.encoder = JsonEncoder
+	b.decoder = JsonDecoder
+	return b.Header("Content-Type", "application/json; charset=utf-8")
+}
+
+// Form sets payload (url.Values or map[string]string) to be encoded as
+// application/x-www-form-urlencoded.
+func (b *RequestBuilder) Form(payload interface{}) *RequestBuilder {
+	b.payload = payload
+	b.encoder = FormURLEncodedEncoder
+	return b.Header("Content-Type", "application/x-www-form-urlencoded")
+}
+
+// Multipart sets payload to a *MultipartEncoder built via
+// NewMultipartEncoder(), negotiating Content-Type (including boundary)
+// from it.
+func (b *RequestBuilder) Multipart(m *MultipartEncoder) *RequestBuilder {
+	b.payload = m
+	b.encoder = MultipartPayloadEncoder
+	return b.Header("Content-Type", m.ContentType())
+}
+
+// XML sets payload to be encoded as application/xml.
+func (b *RequestBuilder) XML(payload interface{}) *RequestBuilder {
+	b.payload = payload
+	b.encoder = XMLEncoder
+	b.decoder = XMLDecoder
+	return b.Header("Content-Type", "application/xml").Header("Accept", "application/xml")
+}
+
+// Protobuf sets payload (a proto.Message) to be encoded as
+// application/x-protobuf.
+func (b *RequestBuilder) Protobuf(payload interface{}) *RequestBuilder {
+	b.payload = payload
+	b.encoder = ProtobufEncoder
+	b.decoder = ProtobufDecoder
+	return b.Header("Content-Type", "application/x-protobuf").Header("Accept", "application/x-protobuf")
+}
+
+// Into sets result, equivalent to RequestOptions.Result. The response is
+// decoded with the decoder chosen by JSON, XML, Protobuf or Decoder, or
+// with the client's decoder (usually JSON) when none was chosen.
+func (b *RequestBuilder) Into(result interface{}) *RequestBuilder {
+	b.result = result
+	return b
+}
+
+// Decoder overrides the response decoder, e.g. XMLDecoder or
+// ProtobufDecoder, when it doesn't already follow from the chosen
+// request encoder.
+func (b *RequestBuilder) Decoder(decoder DataDecoder) *RequestBuilder {
+	b.decoder = decoder
+	return b
+}
+
+// Send executes the built request through DoRequestWithOptions.
+func (b *RequestBuilder) Send() (*http.Response, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	return b.client.DoRequestWithOptions(RequestOptions{
+		Ctx:                   b.ctx,
+		Method:                b.method,
+		Url:                   b.url,
+		Headers:               b.headers,
+		Payload:               b.payload,
+		Result:                b.result,
+		RequestPayloadEncoder: b.encoder,
+		RequestPayloadDecoder: b.decoder,
+		UrlParams:             b.urlParams,
+		RetryPolicy:           b.retryPolicy,
+	})
+}
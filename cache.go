@@ -0,0 +1,239 @@
+package http_client
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	wrapErr "github.com/Chekunin/wraperr"
+)
+
+// CachedResponse is the serializable representation of a cached HTTP
+// response, as stored by a ResponseCache implementation.
+type CachedResponse struct {
+	StatusCode   int
+	Header       http.Header
+	Body         []byte
+	ETag         string
+	LastModified string
+	StoredAt     time.Time
+	// ExpiresAt is zero when the response has no max-age and must always
+	// be revalidated (e.g. a no-cache directive).
+	ExpiresAt time.Time
+}
+
+func (cr *CachedResponse) isFresh() bool {
+	return !cr.ExpiresAt.IsZero() && time.Now().Before(cr.ExpiresAt)
+}
+
+// ResponseCache stores HTTP responses keyed by an opaque cache key built
+// from the request (see (*HttpClient).cacheKey). Implementations must be
+// safe for concurrent use.
+type ResponseCache interface {
+	Get(key string) (*CachedResponse, bool)
+	Put(key string, resp *CachedResponse, ttl time.Duration)
+	Delete(key string)
+}
+
+// CachePolicy controls per-request cache behavior. The zero value,
+// CachePolicyDefault, follows normal HTTP caching semantics.
+type CachePolicy int
+
+const (
+	CachePolicyDefault CachePolicy = iota
+	// CachePolicyBypass skips the cache entirely: no lookup, no store.
+	CachePolicyBypass
+	// CachePolicyForceCache returns a cached entry even if stale,
+	// skipping revalidation, as long as one exists.
+	CachePolicyForceCache
+	// CachePolicyRefreshStale always revalidates/refetches, ignoring a
+	// fresh cached entry.
+	CachePolicyRefreshStale
+)
+
+func methodIsCacheable(method string) bool {
+	switch strings.ToUpper(method) {
+	case http.MethodGet, http.MethodHead:
+		return true
+	default:
+		return false
+	}
+}
+
+// cacheKey derives an opaque cache key from the method, full URL and any
+// client-configured Vary headers.
+func (c *HttpClient) cacheKey(options RequestOptions) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s%s", strings.ToUpper(options.Method), c.baseUrl, options.Url)
+
+	if len(options.UrlParams) > 0 {
+		keys := make([]string, 0, len(options.UrlParams))
+		for k := range options.UrlParams {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		b.WriteByte('?')
+		for i, k := range keys {
+			if i > 0 {
+				b.WriteByte('&')
+			}
+			fmt.Fprintf(&b, "%s=%s", k, options.UrlParams[k])
+		}
+	}
+
+	for _, h := range c.cacheVaryHeaders {
+		if v, ok := options.Headers[h]; ok {
+			fmt.Fprintf(&b, " %s=%s", h, v)
+		} else if v, ok := c.headers[h]; ok {
+			fmt.Fprintf(&b, " %s=%s", h, v)
+		}
+	}
+
+	return b.String()
+}
+
+// parseCacheControl extracts the directives DoRequestWithOptions' cache
+// layer understands from a Cache-Control header.
+func parseCacheControl(header http.Header) (noStore, noCache bool, maxAge time.Duration, hasMaxAge bool) {
+	for _, part := range strings.Split(header.Get("Cache-Control"), ",") {
+		directive := strings.TrimSpace(strings.ToLower(part))
+		switch {
+		case directive == "no-store":
+			noStore = true
+		case directive == "no-cache":
+			noCache = true
+		case strings.HasPrefix(directive, "max-age="):
+			if secs, err := strconv.Atoi(strings.TrimPrefix(directive, "max-age=")); err == nil {
+				maxAge = time.Duration(secs) * time.Second
+				hasMaxAge = true
+			}
+		}
+	}
+	return
+}
+
+// noMaxAgeRetention bounds how long a response without a max-age (but
+// still storable, e.g. for ETag revalidation) is kept by the cache store.
+const noMaxAgeRetention = 24 * time.Hour
+
+// withConditionalHeaders clones headers and adds If-None-Match /
+// If-Modified-Since revalidators from a stale cached entry.
+func withConditionalHeaders(headers map[string]string, cached *CachedResponse) map[string]string {
+	cloned := make(map[string]string, len(headers)+2)
+	for k, v := range headers {
+		cloned[k] = v
+	}
+	if cached.ETag != "" {
+		cloned["If-None-Match"] = cached.ETag
+	}
+	if cached.LastModified != "" {
+		cloned["If-Modified-Since"] = cached.LastModified
+	}
+	return cloned
+}
+
+func responseFromCachedResponse(cr *CachedResponse) *http.Response {
+	body := ioutil.NopCloser(bytes.NewReader(cr.Body))
+	return &http.Response{
+		StatusCode:    cr.StatusCode,
+		Status:        http.StatusText(cr.StatusCode),
+		Header:        cr.Header.Clone(),
+		Body:          body,
+		ContentLength: int64(len(cr.Body)),
+	}
+}
+
+// respondFromCache decodes options.Result straight from a cached entry,
+// without touching the network.
+func (c *HttpClient) respondFromCache(options RequestOptions, cr *CachedResponse) (*http.Response, error) {
+	if options.Result != nil {
+		if err := options.RequestPayloadDecoder(bytes.NewReader(cr.Body), options.Result); err != nil {
+			return nil, wrapErr.Wrap(fmt.Errorf("decode cached resp.Body"), err)
+		}
+	}
+	return responseFromCachedResponse(cr), nil
+}
+
+// finalizeResponseWithCache is finalizeResponse's cache-aware counterpart:
+// it handles 304 revalidation against staleCached and, on a fresh 200,
+// stores the response before returning it.
+func (c *HttpClient) finalizeResponseWithCache(options RequestOptions, a attemptResult, cacheKey string, staleCached *CachedResponse) (*http.Response, error) {
+	resp := a.resp
+
+	if resp.StatusCode == http.StatusNotModified && staleCached != nil {
+		defer resp.Body.Close()
+		refreshed := *staleCached
+		if etag := resp.Header.Get("ETag"); etag != "" {
+			refreshed.ETag = etag
+		}
+		if lm := resp.Header.Get("Last-Modified"); lm != "" {
+			refreshed.LastModified = lm
+		}
+		refreshed.StoredAt = time.Now()
+		_, _, maxAge, hasMaxAge := parseCacheControl(resp.Header)
+		ttl := noMaxAgeRetention
+		refreshed.ExpiresAt = time.Time{}
+		if hasMaxAge {
+			ttl = maxAge
+			refreshed.ExpiresAt = time.Now().Add(maxAge)
+		}
+		c.cache.Put(cacheKey, &refreshed, ttl)
+		return c.respondFromCache(options, &refreshed)
+	}
+
+	defer resp.Body.Close()
+	if err := c.statusError(resp, a); err != nil {
+		return nil, err
+	}
+
+	noStore, noCache, maxAge, hasMaxAge := parseCacheControl(resp.Header)
+	var bodyBytes []byte
+	var decodeSrc = resp.Body
+	if !noStore {
+		var err error
+		bodyBytes, err = ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return nil, wrapErr.Wrap(fmt.Errorf("read resp.Body for cache"), err)
+		}
+	}
+
+	if options.Result != nil {
+		if bodyBytes != nil {
+			decodeSrc = ioutil.NopCloser(bytes.NewReader(bodyBytes))
+		}
+		if err := options.RequestPayloadDecoder(decodeSrc, options.Result); err != nil {
+			if c.debugMode {
+				err = wrapErr.Wrap(fmt.Errorf("curl: %s", a.curl), err)
+				err = wrapErr.Wrap(fmt.Errorf("%s", a.took), err)
+			}
+			err = wrapErr.Wrap(fmt.Errorf("decode resp.Body"), err)
+			return nil, err
+		}
+	}
+
+	if !noStore {
+		ttl := noMaxAgeRetention
+		var expiresAt time.Time
+		if hasMaxAge && !noCache {
+			ttl = maxAge
+			expiresAt = time.Now().Add(maxAge)
+		}
+		c.cache.Put(cacheKey, &CachedResponse{
+			StatusCode:   resp.StatusCode,
+			Header:       resp.Header.Clone(),
+			Body:         bodyBytes,
+			ETag:         resp.Header.Get("ETag"),
+			LastModified: resp.Header.Get("Last-Modified"),
+			StoredAt:     time.Now(),
+			ExpiresAt:    expiresAt,
+		}, ttl)
+		resp.Body = ioutil.NopCloser(bytes.NewReader(bodyBytes))
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,113 @@
+package http_client
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// diskCacheFile is the on-disk representation of a cache entry: headers
+// and body are kept in one file, similar to how HTTP disk caches
+// typically persist bodies alongside their metadata.
+type diskCacheFile struct {
+	Resp      *CachedResponse
+	ExpiresAt time.Time
+}
+
+// diskCache is a ResponseCache that persists each entry under
+// dir/<sha256(key)>.
+type diskCache struct {
+	dir string
+}
+
+// NewDiskCache returns a disk-backed ResponseCache rooted at dir. dir is
+// created on the first Put if it doesn't already exist.
+func NewDiskCache(dir string) ResponseCache {
+	return &diskCache{dir: dir}
+}
+
+func (d *diskCache) pathFor(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(d.dir, hex.EncodeToString(sum[:]))
+}
+
+func (d *diskCache) Get(key string) (*CachedResponse, bool) {
+	data, err := os.ReadFile(d.pathFor(key))
+	if err != nil {
+		return nil, false
+	}
+	var f diskCacheFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, false
+	}
+	if !f.ExpiresAt.IsZero() && time.Now().After(f.ExpiresAt) {
+		os.Remove(d.pathFor(key))
+		return nil, false
+	}
+	return f.Resp, true
+}
+
+func (d *diskCache) Put(key string, resp *CachedResponse, ttl time.Duration) {
+	if err := os.MkdirAll(d.dir, 0o755); err != nil {
+		return
+	}
+	var expiresAt time.Time
+	if ttl > 0 {
+		expiresAt = time.Now().Add(ttl)
+	}
+	data, err := json.Marshal(diskCacheFile{Resp: resp, ExpiresAt: expiresAt})
+	if err != nil {
+		return
+	}
+	_ = os.WriteFile(d.pathFor(key), data, 0o644)
+}
+
+func (d *diskCache) Delete(key string) {
+	os.Remove(d.pathFor(key))
+}
+
+// twoTierCache fronts a slower ResponseCache (typically disk-backed) with
+// a faster one (typically in-memory), populating the front tier on a
+// back-tier hit.
+type twoTierCache struct {
+	front ResponseCache
+	back  ResponseCache
+}
+
+// NewTwoTierCache combines front and back into a single ResponseCache:
+// reads check front first, falling back to back and repopulating front;
+// writes and deletes go to both.
+func NewTwoTierCache(front, back ResponseCache) ResponseCache {
+	return &twoTierCache{front: front, back: back}
+}
+
+func (t *twoTierCache) Get(key string) (*CachedResponse, bool) {
+	if resp, ok := t.front.Get(key); ok {
+		return resp, true
+	}
+	resp, ok := t.back.Get(key)
+	if ok {
+		t.front.Put(key, resp, 0)
+	}
+	return resp, ok
+}
+
+func (t *twoTierCache) Put(key string, resp *CachedResponse, ttl time.Duration) {
+	t.front.Put(key, resp, ttl)
+	t.back.Put(key, resp, ttl)
+}
+
+func (t *twoTierCache) Delete(key string) {
+	t.front.Delete(key)
+	t.back.Delete(key)
+}
+
+// NewDefaultResponseCache returns the default two-tier ResponseCache: an
+// in-memory LRU of maxMemoryItems entries fronting a disk-backed store
+// rooted at cacheDir.
+func NewDefaultResponseCache(cacheDir string, maxMemoryItems int) ResponseCache {
+	return NewTwoTierCache(NewMemoryCache(maxMemoryItems), NewDiskCache(cacheDir))
+}
@@ -0,0 +1,90 @@
+package http_client
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+type memoryCacheEntry struct {
+	key  string
+	resp *CachedResponse
+	// expiresAt is when the store should evict the entry outright,
+	// independent of CachedResponse.ExpiresAt (which governs freshness,
+	// not retention).
+	expiresAt time.Time
+}
+
+// memoryCache is a goroutine-safe, fixed-capacity LRU ResponseCache.
+type memoryCache struct {
+	mu       sync.Mutex
+	maxItems int
+	order    *list.List
+	items    map[string]*list.Element
+}
+
+// NewMemoryCache returns an in-memory LRU ResponseCache holding at most
+// maxItems entries.
+func NewMemoryCache(maxItems int) ResponseCache {
+	return &memoryCache{
+		maxItems: maxItems,
+		order:    list.New(),
+		items:    make(map[string]*list.Element),
+	}
+}
+
+func (m *memoryCache) Get(key string) (*CachedResponse, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	el, ok := m.items[key]
+	if !ok {
+		return nil, false
+	}
+	entry := el.Value.(*memoryCacheEntry)
+	if !entry.expiresAt.IsZero() && time.Now().After(entry.expiresAt) {
+		m.order.Remove(el)
+		delete(m.items, key)
+		return nil, false
+	}
+	m.order.MoveToFront(el)
+	return entry.resp, true
+}
+
+func (m *memoryCache) Put(key string, resp *CachedResponse, ttl time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var expiresAt time.Time
+	if ttl > 0 {
+		expiresAt = time.Now().Add(ttl)
+	}
+
+	if el, ok := m.items[key]; ok {
+		el.Value = &memoryCacheEntry{key: key, resp: resp, expiresAt: expiresAt}
+		m.order.MoveToFront(el)
+		return
+	}
+
+	el := m.order.PushFront(&memoryCacheEntry{key: key, resp: resp, expiresAt: expiresAt})
+	m.items[key] = el
+
+	for m.maxItems > 0 && m.order.Len() > m.maxItems {
+		oldest := m.order.Back()
+		if oldest == nil {
+			break
+		}
+		m.order.Remove(oldest)
+		delete(m.items, oldest.Value.(*memoryCacheEntry).key)
+	}
+}
+
+func (m *memoryCache) Delete(key string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if el, ok := m.items[key]; ok {
+		m.order.Remove(el)
+		delete(m.items, key)
+	}
+}
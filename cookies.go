@@ -0,0 +1,34 @@
+package http_client
+
+import (
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+)
+
+// NewDefaultCookieJar returns a goroutine-safe, in-memory http.CookieJar
+// suitable as HttpClientParams.CookieJar when no custom persistence is
+// needed.
+func NewDefaultCookieJar() http.CookieJar {
+	jar, _ := cookiejar.New(nil)
+	return jar
+}
+
+// Cookies returns the cookies the client's jar would send in a request to
+// u. It returns nil if no CookieJar was configured.
+func (c *HttpClient) Cookies(u *url.URL) []*http.Cookie {
+	if c.httpClient2.Jar == nil {
+		return nil
+	}
+	return c.httpClient2.Jar.Cookies(u)
+}
+
+// SetCookies stores cookies as if they had been received in a response
+// from u. It is a no-op if no CookieJar was configured. Middleware (e.g.
+// auth token refresh) can use this to rotate session cookies.
+func (c *HttpClient) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	if c.httpClient2.Jar == nil {
+		return
+	}
+	c.httpClient2.Jar.SetCookies(u, cookies)
+}
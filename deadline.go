@@ -0,0 +1,104 @@
+package http_client
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// DeadlineExceededError is returned by a *DeadlineReader once its read
+// deadline elapses. It is a distinct type from context.DeadlineExceeded,
+// so errors.Is can tell which one fired.
+type DeadlineExceededError struct{}
+
+func (DeadlineExceededError) Error() string { return "http_client: read deadline exceeded" }
+
+// DeadlineReader wraps an io.ReadCloser, failing reads once its deadline
+// elapses, in the spirit of net's deadlineTimer: a timer closes the
+// underlying reader to unblock any in-flight Read, and subsequent reads
+// report DeadlineExceededError instead of the resulting "use of closed"
+// error. SetDeadline may be called again to push the deadline back; a
+// zero time.Time disables it.
+type DeadlineReader struct {
+	mu      sync.Mutex
+	r       io.ReadCloser
+	timer   *time.Timer
+	expired bool
+}
+
+func newDeadlineReader(r io.ReadCloser) *DeadlineReader {
+	return &DeadlineReader{r: r}
+}
+
+func (d *DeadlineReader) SetDeadline(t time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.timer != nil {
+		d.timer.Stop()
+	}
+	d.expired = false
+	if t.IsZero() {
+		d.timer = nil
+		return
+	}
+	d.timer = time.AfterFunc(time.Until(t), func() {
+		d.mu.Lock()
+		d.expired = true
+		d.mu.Unlock()
+		d.r.Close()
+	})
+}
+
+func (d *DeadlineReader) Read(p []byte) (int, error) {
+	d.mu.Lock()
+	if d.expired {
+		d.mu.Unlock()
+		return 0, DeadlineExceededError{}
+	}
+	d.mu.Unlock()
+
+	n, err := d.r.Read(p)
+	if err != nil {
+		d.mu.Lock()
+		expired := d.expired
+		d.mu.Unlock()
+		if expired {
+			return n, DeadlineExceededError{}
+		}
+	}
+	return n, err
+}
+
+func (d *DeadlineReader) Close() error {
+	d.mu.Lock()
+	if d.timer != nil {
+		d.timer.Stop()
+	}
+	d.mu.Unlock()
+	return d.r.Close()
+}
+
+// withConnectDeadline returns a context that is canceled if dialing isn't
+// done by deadline, using httptrace to stop the timer once connected so
+// it never fires against an already-established connection. A zero
+// deadline returns ctx unchanged.
+func withConnectDeadline(ctx context.Context, deadline time.Time) context.Context {
+	if deadline.IsZero() {
+		return ctx
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	timer := time.AfterFunc(time.Until(deadline), cancel)
+	trace := &httptrace.ClientTrace{
+		GotConn: func(httptrace.GotConnInfo) {
+			timer.Stop()
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			timer.Stop()
+		},
+	}
+	return httptrace.WithClientTrace(ctx, trace)
+}
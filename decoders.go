@@ -3,8 +3,12 @@ package http_client
 import (
 	"encoding/gob"
 	"encoding/json"
+	"encoding/xml"
 	"fmt"
 	"io"
+	"io/ioutil"
+
+	"google.golang.org/protobuf/proto"
 )
 
 func GobDecoder(reader io.Reader, res interface{}) error {
@@ -22,3 +26,28 @@ func JsonDecoder(reader io.Reader, res interface{}) error {
 	}
 	return nil
 }
+
+func XMLDecoder(reader io.Reader, res interface{}) error {
+	dec := xml.NewDecoder(reader)
+	if err := dec.Decode(res); err != nil {
+		return fmt.Errorf("xml dec.Decode: %s", err)
+	}
+	return nil
+}
+
+// ProtobufDecoder decodes a protobuf binary body into a res that
+// implements proto.Message.
+func ProtobufDecoder(reader io.Reader, res interface{}) error {
+	msg, ok := res.(proto.Message)
+	if !ok {
+		return fmt.Errorf("ProtobufDecoder: res does not implement proto.Message")
+	}
+	data, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return fmt.Errorf("read body: %s", err)
+	}
+	if err := proto.Unmarshal(data, msg); err != nil {
+		return fmt.Errorf("proto.Unmarshal: %s", err)
+	}
+	return nil
+}
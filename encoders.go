@@ -4,8 +4,12 @@ import (
 	"bytes"
 	"encoding/gob"
 	"encoding/json"
+	"encoding/xml"
 	"fmt"
 	"io"
+	"net/url"
+
+	"google.golang.org/protobuf/proto"
 )
 
 func GobEncoder(payload interface{}) (io.Reader, error) {
@@ -29,3 +33,62 @@ func JsonEncoder(payload interface{}) (io.Reader, error) {
 	buf := bytes.NewBuffer(data)
 	return buf, nil
 }
+
+// FormURLEncodedEncoder encodes an url.Values or map[string]string payload
+// as application/x-www-form-urlencoded.
+func FormURLEncodedEncoder(payload interface{}) (io.Reader, error) {
+	if payload == nil {
+		return nil, nil
+	}
+	switch v := payload.(type) {
+	case url.Values:
+		return bytes.NewBufferString(v.Encode()), nil
+	case map[string]string:
+		values := make(url.Values, len(v))
+		for key, val := range v {
+			values.Set(key, val)
+		}
+		return bytes.NewBufferString(values.Encode()), nil
+	default:
+		return nil, fmt.Errorf("FormURLEncodedEncoder: unsupported payload type %T", payload)
+	}
+}
+
+// MultipartPayloadEncoder encodes a *MultipartEncoder payload built via
+// NewMultipartEncoder(). It is set automatically by
+// RequestBuilder.Multipart.
+func MultipartPayloadEncoder(payload interface{}) (io.Reader, error) {
+	if payload == nil {
+		return nil, nil
+	}
+	m, ok := payload.(*MultipartEncoder)
+	if !ok {
+		return nil, fmt.Errorf("MultipartPayloadEncoder: unsupported payload type %T", payload)
+	}
+	return m.Encode()
+}
+
+func XMLEncoder(payload interface{}) (io.Reader, error) {
+	data, err := xml.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("xml Marshal: %s", err)
+	}
+	return bytes.NewBuffer(data), nil
+}
+
+// ProtobufEncoder encodes a proto.Message payload using protobuf binary
+// encoding.
+func ProtobufEncoder(payload interface{}) (io.Reader, error) {
+	if payload == nil {
+		return nil, nil
+	}
+	msg, ok := payload.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("ProtobufEncoder: payload does not implement proto.Message")
+	}
+	data, err := proto.Marshal(msg)
+	if err != nil {
+		return nil, fmt.Errorf("proto.Marshal: %s", err)
+	}
+	return bytes.NewBuffer(data), nil
+}
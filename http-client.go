@@ -8,6 +8,7 @@ import (
 	"io/ioutil"
 	"net"
 	"net/http"
+	"net/http/httptrace"
 	"time"
 
 	wrapErr "github.com/Chekunin/wraperr"
@@ -28,6 +29,12 @@ type HttpClient struct {
 	contextRequestId      string
 	headerKeyRequestID    string
 	debugMode             bool
+	before                []RequestFunc
+	after                 []ResponseFunc
+	endpoint              Endpoint
+	retryPolicy           *RetryPolicy
+	cache                 ResponseCache
+	cacheVaryHeaders      []string
 }
 
 type HttpClientParams struct {
@@ -42,6 +49,31 @@ type HttpClientParams struct {
 	ContextRequestId      string
 	HeaderKeyRequestID    string
 	DebugMode             bool
+	// Before is run, in order, with the outgoing *http.Request right
+	// before it is sent.
+	Before []RequestFunc
+	// After is run, in order, with the *http.Response right after it is
+	// received, before error handling and result decoding.
+	After []ResponseFunc
+	// Middlewares wrap DoRequestWithOptions, outermost first, letting
+	// callers slot in tracing, retries, metrics, etc. without forking
+	// the library.
+	Middlewares []Middleware
+	// RetryPolicy, when set, is the default retry behavior for every
+	// request. It can be overridden per request via
+	// RequestOptions.RetryPolicy.
+	RetryPolicy *RetryPolicy
+	// Cache, when set, enables response caching for safe methods
+	// (GET/HEAD). See NewDefaultResponseCache for the built-in two-tier
+	// implementation.
+	Cache ResponseCache
+	// CacheVaryHeaders lists request header names that vary the cache
+	// key, e.g. "Authorization" or "Accept-Language".
+	CacheVaryHeaders []string
+	// CookieJar, when set, is assigned to the underlying http.Client so
+	// cookies are persisted across requests. Defaults to nil (no jar).
+	// See NewDefaultCookieJar for a ready-to-use in-memory jar.
+	CookieJar http.CookieJar
 }
 
 func NewHttpClient(params HttpClientParams) *HttpClient {
@@ -59,12 +91,18 @@ func NewHttpClient(params HttpClientParams) *HttpClient {
 		httpClient2: &http.Client{
 			Timeout:   params.Timeout,
 			Transport: transport,
+			Jar:       params.CookieJar,
 		},
 		requestPayloadEncoder: params.RequestPayloadEncoder,
 		requestPayloadDecoder: params.RequestPayloadDecoder,
 		contextRequestId:      params.ContextRequestId,
 		headerKeyRequestID:    params.HeaderKeyRequestID,
 		debugMode:             params.DebugMode,
+		before:                params.Before,
+		after:                 params.After,
+		retryPolicy:           params.RetryPolicy,
+		cache:                 params.Cache,
+		cacheVaryHeaders:      params.CacheVaryHeaders,
 	}
 	if client.requestPayloadEncoder == nil {
 		client.requestPayloadEncoder = JsonEncoder
@@ -72,6 +110,7 @@ func NewHttpClient(params HttpClientParams) *HttpClient {
 	if client.requestPayloadDecoder == nil {
 		client.requestPayloadDecoder = JsonDecoder
 	}
+	client.endpoint = chainMiddlewares(client.doRequestWithOptions, params.Middlewares)
 	return &client
 }
 
@@ -121,6 +160,39 @@ type RequestOptions struct {
 	RequestPayloadDecoder DataDecoder
 	UrlParams             map[string]string
 	AfterCallback         func(req *http.Request, resp *http.Response)
+	// RetryPolicy overrides HttpClientParams.RetryPolicy for this request
+	// only. Leave nil to use the client's default.
+	RetryPolicy *RetryPolicy
+	// CachePolicy overrides the default caching behavior for this
+	// request only.
+	CachePolicy CachePolicy
+	// Cookies are appended to this request only, without touching the
+	// client's CookieJar.
+	Cookies []*http.Cookie
+	// Deadline bounds the whole request (including retries). The client's
+	// Timeout still applies to each attempt as well, so a Deadline later
+	// than it does not extend it. Zero means no deadline.
+	Deadline time.Time
+	// ConnectDeadline bounds only the dial/TLS-handshake phase of each
+	// attempt. Zero means no deadline.
+	ConnectDeadline time.Time
+	// ReadDeadline bounds reads from the response body, independent of
+	// Deadline/ConnectDeadline/the client Timeout, letting callers impose
+	// tight byte-read deadlines on streaming responses. Zero means no
+	// deadline. A *DeadlineReader is returned as resp.Body when set, and
+	// SetDeadline may be called on it again to push the deadline back. A
+	// response served from or stored in the cache has an in-memory
+	// resp.Body instead.
+	ReadDeadline time.Time
+	// StreamDecoder, if set, switches the response to streaming mode:
+	// the normal Result decode is skipped, resp.Body is handed to the
+	// decoder, and the decoder drives OnItem for each item it parses until
+	// EOF or the request's context is done. resp.Body is closed once
+	// decoding stops. If OnItem is nil, parsed items are discarded.
+	StreamDecoder StreamDecoder
+	// OnItem receives each item StreamDecoder parses. An error returned
+	// here stops decoding and is returned from DoRequestWithOptions.
+	OnItem func(item interface{}) error
 }
 
 func (c HttpClient) setDefaultOptions(opt *RequestOptions) {
@@ -140,35 +212,165 @@ func (c HttpClient) setDefaultOptions(opt *RequestOptions) {
 	if opt.RequestPayloadDecoder == nil {
 		opt.RequestPayloadDecoder = c.requestPayloadDecoder
 	}
+	if opt.StreamDecoder != nil && opt.OnItem == nil {
+		opt.OnItem = func(interface{}) error { return nil }
+	}
 }
 
 func (c *HttpClient) DoRequestWithOptions(options RequestOptions) (*http.Response, error) {
 	c.setDefaultOptions(&options)
+	return c.endpoint(options.Ctx, options)
+}
+
+// doRequestWithOptions is the innermost Endpoint: it performs the actual
+// HTTP round trip, retrying according to the effective RetryPolicy.
+// Middlewares registered via HttpClientParams.Middlewares wrap this
+// Endpoint; it must not be called directly, use DoRequestWithOptions so
+// the middleware chain runs and the default options are applied.
+func (c *HttpClient) doRequestWithOptions(ctx context.Context, options RequestOptions) (*http.Response, error) {
+	if !options.Deadline.IsZero() {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, options.Deadline)
+		defer cancel()
+	}
+
 	payloadReader, err := options.RequestPayloadEncoder(options.Payload)
 	if err != nil {
 		err = wrapErr.Wrap(fmt.Errorf("requestPayloadEncoder"), err)
 		return nil, err
 	}
-	reqBuffer := bytes.NewBuffer(make([]byte, 0))
-	if options.AfterCallback != nil {
-		b := bytes.NewBuffer(make([]byte, 0))
-		reader := io.TeeReader(payloadReader, b)
-
-		_, err = io.Copy(reqBuffer, reader)
+	var payloadBytes []byte
+	if payloadReader != nil {
+		payloadBytes, err = ioutil.ReadAll(payloadReader)
 		if err != nil {
 			return nil, err
 		}
-		payloadReader = ioutil.NopCloser(b)
 	}
+
+	var cacheKey string
+	var staleCached *CachedResponse
+	cacheable := c.cache != nil && options.StreamDecoder == nil && options.CachePolicy != CachePolicyBypass && methodIsCacheable(options.Method)
+	if cacheable {
+		cacheKey = c.cacheKey(options)
+		if cached, ok := c.cache.Get(cacheKey); ok {
+			if options.CachePolicy != CachePolicyRefreshStale && (options.CachePolicy == CachePolicyForceCache || cached.isFresh()) {
+				return c.respondFromCache(options, cached)
+			}
+			staleCached = cached
+			options.Headers = withConditionalHeaders(options.Headers, cached)
+		}
+	}
+
+	policy := c.retryPolicy
+	if options.RetryPolicy != nil {
+		policy = options.RetryPolicy
+	}
+	maxAttempts := policy.maxAttempts()
+
+	var lastErr error
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		attemptCtx := ctx
+		var cancel context.CancelFunc
+		if policy != nil && policy.PerAttemptTimeout > 0 {
+			attemptCtx, cancel = context.WithTimeout(ctx, policy.PerAttemptTimeout)
+		}
+
+		var body io.Reader
+		if payloadBytes != nil {
+			body = bytes.NewReader(payloadBytes)
+		}
+		a, err := c.doAttempt(attemptCtx, options, body)
+		if cancel != nil {
+			cancel()
+		}
+		lastErr = err
+
+		methodRetryable := policy != nil && policy.methodIsRetryable(options.Method, a.bodySent)
+		retry := attempt < maxAttempts-1 && methodRetryable && ctx.Err() == nil
+		if retry {
+			if err != nil {
+				retry = policy.isRetryableError(a.rawErr)
+			} else {
+				retry = policy.isRetryableStatus(a.resp.StatusCode)
+			}
+		}
+		if !retry {
+			if err != nil {
+				return nil, err
+			}
+			if cacheable {
+				return c.finalizeResponseWithCache(options, a, cacheKey, staleCached)
+			}
+			return c.finalizeResponse(options, a)
+		}
+
+		if a.resp != nil {
+			a.resp.Body.Close()
+		}
+		backoff := policy.backoff(attempt)
+		if policy.RespectRetryAfter && a.retryAfter > 0 {
+			backoff = a.retryAfter
+		}
+		if policy.OnRetry != nil {
+			policy.OnRetry(attempt+1, err, backoff)
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(backoff):
+		}
+	}
+
+	return nil, lastErr
+}
+
+// attemptResult carries a single attempt's response plus the debug
+// context finalizeResponse needs to keep error messages consistent with
+// the pre-retry behavior.
+type attemptResult struct {
+	resp       *http.Response
+	curl       *http2curl.CurlCommand
+	took       string
+	retryAfter time.Duration
+	// rawErr is the error httpClient2.Do (or request construction)
+	// returned, before it gets wrapped in debug/context information. The
+	// retry loop checks this, not the wrapped error, so errors.Is can
+	// still see through to stdlib sentinels like io.EOF.
+	rawErr error
+	// bodySent reports whether the request body had started going out on
+	// the wire when the attempt failed. It is only meaningful when the
+	// request carried a payload.
+	bodySent bool
+}
+
+// doAttempt performs a single HTTP round trip, running the Before/After
+// hooks and the AfterCallback, but leaving error handling and result
+// decoding to finalizeResponse so the retry loop can inspect the status
+// code of a failed attempt without consuming its body. On a network-level
+// error it returns a non-nil error and a result carrying only rawErr and
+// bodySent; otherwise it returns the
+// response (with an open, undrained body) for the caller to either retry
+// or finalize.
+func (c *HttpClient) doAttempt(ctx context.Context, options RequestOptions, body io.Reader) (attemptResult, error) {
+	ctx = withConnectDeadline(ctx, options.ConnectDeadline)
+
+	var bodySent bool
+	if body != nil {
+		ctx = httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+			WroteRequest: func(httptrace.WroteRequestInfo) {
+				bodySent = true
+			},
+		})
+	}
+
 	req, err := http.NewRequestWithContext(
-		options.Ctx,
+		ctx,
 		options.Method,
 		fmt.Sprintf("%s%s", c.baseUrl, options.Url),
-		payloadReader,
+		body,
 	)
 	if err != nil {
-		err = wrapErr.Wrap(fmt.Errorf("new request with context"), err)
-		return nil, err
+		return attemptResult{rawErr: err}, wrapErr.Wrap(fmt.Errorf("new request with context"), err)
 	}
 
 	q := req.URL.Query()
@@ -178,8 +380,8 @@ func (c *HttpClient) DoRequestWithOptions(options RequestOptions) (*http.Respons
 	req.URL.RawQuery = q.Encode()
 
 	req.Header.Set("Accept", "application/json; charset=utf-8")
-	if options.Ctx != nil {
-		if requestID, has := c.fromContextRequestId(options.Ctx); has {
+	if ctx != nil {
+		if requestID, has := c.fromContextRequestId(ctx); has {
 			req.Header.Set(c.headerKeyRequestID, requestID)
 		}
 	}
@@ -189,6 +391,14 @@ func (c *HttpClient) DoRequestWithOptions(options RequestOptions) (*http.Respons
 	for i, v := range options.Headers {
 		req.Header.Set(i, v)
 	}
+	for _, cookie := range options.Cookies {
+		req.AddCookie(cookie)
+	}
+
+	for _, before := range c.before {
+		ctx = before(ctx, req)
+	}
+	req = req.WithContext(ctx)
 
 	var curl *http2curl.CurlCommand
 	var t time.Time
@@ -199,34 +409,84 @@ func (c *HttpClient) DoRequestWithOptions(options RequestOptions) (*http.Respons
 	resp, err := c.httpClient2.Do(req)
 	requestTook := fmt.Sprintf("request took %f microseconds", float64(time.Now().UnixNano()-t.UnixNano())/float64(time.Microsecond))
 	if err != nil {
+		rawErr := err
 		if c.debugMode {
 			err = wrapErr.Wrap(fmt.Errorf("curl: %s", curl), err)
 			err = wrapErr.Wrap(fmt.Errorf("%s", requestTook), err)
 		}
 		err = wrapErr.Wrap(fmt.Errorf("do http request"), err)
-		return nil, err
+		return attemptResult{rawErr: rawErr, bodySent: bodySent}, err
 	}
-	defer resp.Body.Close()
 
+	if !options.ReadDeadline.IsZero() {
+		dr := newDeadlineReader(resp.Body)
+		dr.SetDeadline(options.ReadDeadline)
+		resp.Body = dr
+	}
+
+	after := c.after
 	if options.AfterCallback != nil {
-		req.Body = ioutil.NopCloser(reqBuffer)
-		options.AfterCallback(req, resp)
+		after = append(append([]ResponseFunc{}, after...), func(ctx context.Context, resp *http.Response) context.Context {
+			if payloadBytes, ok := body.(*bytes.Reader); ok {
+				_, _ = payloadBytes.Seek(0, io.SeekStart)
+				req.Body = ioutil.NopCloser(payloadBytes)
+			}
+			options.AfterCallback(req, resp)
+			return ctx
+		})
+	}
+	for _, afterFunc := range after {
+		ctx = afterFunc(ctx, resp)
 	}
 
+	retryAfter, _ := retryAfterDelay(resp)
+	return attemptResult{resp: resp, curl: curl, took: requestTook, retryAfter: retryAfter, bodySent: bodySent}, nil
+}
+
+// statusError reports the error to return for a response the configured
+// IsError/ErrorHandler consider a failure, or nil if resp isn't one. It's
+// shared by finalizeResponse and its cache-aware counterpart so both
+// paths stay in sync on debug/error-handler behavior.
+func (c *HttpClient) statusError(resp *http.Response, a attemptResult) error {
+	if !c.defaultIsError(resp) {
+		return nil
+	}
+	var err error
+	if c.debugMode {
+		err = wrapErr.Wrap(fmt.Errorf("curl: %s", a.curl), err)
+		err = wrapErr.Wrap(fmt.Errorf("%s", a.took), err)
+	}
+	return wrapErr.Wrap(fmt.Errorf("http status code=%d curl", resp.StatusCode), c.defaultErrorHandler(resp.Body))
+}
+
+// finalizeResponse applies default error handling and result decoding to
+// a response that the retry loop has decided to accept (i.e. it won't be
+// retried further).
+func (c *HttpClient) finalizeResponse(options RequestOptions, a attemptResult) (*http.Response, error) {
+	resp := a.resp
+
 	if c.defaultIsError(resp) {
-		var err error
-		if c.debugMode {
-			err = wrapErr.Wrap(fmt.Errorf("curl: %s", curl), err)
-			err = wrapErr.Wrap(fmt.Errorf("%s", requestTook), err)
+		defer resp.Body.Close()
+		return nil, c.statusError(resp, a)
+	}
+	if options.StreamDecoder != nil {
+		if err := options.StreamDecoder.Decode(resp.Body, options.OnItem); err != nil {
+			resp.Body.Close()
+			if c.debugMode {
+				err = wrapErr.Wrap(fmt.Errorf("curl: %s", a.curl), err)
+				err = wrapErr.Wrap(fmt.Errorf("%s", a.took), err)
+			}
+			return nil, wrapErr.Wrap(fmt.Errorf("stream decode resp.Body"), err)
 		}
-		err = wrapErr.Wrap(fmt.Errorf("http status code=%d curl", resp.StatusCode), c.defaultErrorHandler(resp.Body))
-		return nil, err
+		resp.Body.Close()
+		return resp, nil
 	}
 	if options.Result != nil {
+		defer resp.Body.Close()
 		if err := options.RequestPayloadDecoder(resp.Body, options.Result); err != nil {
 			if c.debugMode {
-				err = wrapErr.Wrap(fmt.Errorf("curl: %s", curl), err)
-				err = wrapErr.Wrap(fmt.Errorf("%s", requestTook), err)
+				err = wrapErr.Wrap(fmt.Errorf("curl: %s", a.curl), err)
+				err = wrapErr.Wrap(fmt.Errorf("%s", a.took), err)
 			}
 			err = wrapErr.Wrap(fmt.Errorf("decode resp.Body"), err)
 			return nil, err
@@ -7,10 +7,12 @@ import (
 	"errors"
 	"io"
 	"io/ioutil"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -237,6 +239,316 @@ func TestError404(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func TestBeforeHookContextPropagation(t *testing.T) {
+	hits := 0
+	handler = func(rw http.ResponseWriter, req *http.Request) {
+		hits++
+		rw.WriteHeader(http.StatusOK)
+	}
+
+	beforeClient := NewHttpClient(HttpClientParams{
+		BaseUrl: hs.URL,
+		Timeout: 2 * time.Second,
+		Before: []RequestFunc{
+			func(ctx context.Context, req *http.Request) context.Context {
+				ctx, cancel := context.WithCancel(ctx)
+				cancel()
+				return ctx
+			},
+		},
+	})
+
+	_, err := beforeClient.DoRequestWithOptions(RequestOptions{
+		Ctx:    context.Background(),
+		Method: "GET",
+		Url:    "/qwe",
+	})
+	assert.Error(t, err)
+	assert.True(t, strings.Contains(err.Error(), "context canceled"))
+	assert.Equal(t, 0, hits)
+}
+
+func TestRequestBuilder(t *testing.T) {
+	type payloadStruct struct {
+		A string `json:"a"`
+	}
+	type responseStruct struct {
+		Ok bool `json:"ok"`
+	}
+
+	handler = func(rw http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != "/builder" {
+			t.Error("Bad path!")
+		}
+		assert.Equal(t, "v", req.URL.Query().Get("k"))
+		assert.Equal(t, "Y", req.Header.Get("X"))
+
+		var body payloadStruct
+		err := json.NewDecoder(req.Body).Decode(&body)
+		assert.NoError(t, err)
+		assert.Equal(t, payloadStruct{A: "b"}, body)
+
+		io.WriteString(rw, `{"ok":true}`)
+	}
+
+	var resp responseStruct
+	_, err := client.R(context.Background()).
+		Post("/builder").
+		Query("k", "v").
+		Header("X", "Y").
+		JSON(payloadStruct{A: "b"}).
+		Into(&resp).
+		Send()
+	assert.NoError(t, err)
+	assert.True(t, resp.Ok)
+}
+
+func TestResponseCache(t *testing.T) {
+	cachingClient := NewHttpClient(HttpClientParams{
+		BaseUrl: hs.URL,
+		Timeout: time.Second,
+		Cache:   NewMemoryCache(10),
+	})
+
+	hits := 0
+	handler = func(rw http.ResponseWriter, req *http.Request) {
+		hits++
+		rw.Header().Set("Cache-Control", "max-age=60")
+		rw.Header().Set("ETag", `"v1"`)
+		io.WriteString(rw, `{"data":"cached"}`)
+	}
+
+	type responseStruct struct {
+		Data string `json:"data"`
+	}
+	var resp1, resp2 responseStruct
+	_, err := cachingClient.GetRequest(context.Background(), "/cacheme", nil, &resp1)
+	assert.NoError(t, err)
+	_, err = cachingClient.GetRequest(context.Background(), "/cacheme", nil, &resp2)
+	assert.NoError(t, err)
+
+	assert.Equal(t, 1, hits)
+	assert.Equal(t, resp1, resp2)
+}
+
+func TestCookieJar(t *testing.T) {
+	jarClient := NewHttpClient(HttpClientParams{
+		BaseUrl:   hs.URL,
+		Timeout:   time.Second,
+		CookieJar: NewDefaultCookieJar(),
+	})
+
+	handler = func(rw http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/setcookie":
+			http.SetCookie(rw, &http.Cookie{Name: "sess", Value: "abc"})
+		case "/checkcookie":
+			cookie, err := req.Cookie("sess")
+			assert.NoError(t, err)
+			assert.Equal(t, "abc", cookie.Value)
+		default:
+			t.Error("Bad path!")
+		}
+	}
+
+	_, err := jarClient.GetRequest(context.Background(), "/setcookie", nil, nil)
+	assert.NoError(t, err)
+	_, err = jarClient.GetRequest(context.Background(), "/checkcookie", nil, nil)
+	assert.NoError(t, err)
+}
+
+func TestRetryPolicy(t *testing.T) {
+	attempts := 0
+	handler = func(rw http.ResponseWriter, req *http.Request) {
+		attempts++
+		if attempts < 3 {
+			rw.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		rw.WriteHeader(http.StatusOK)
+	}
+
+	_, err := client.DoRequestWithOptions(RequestOptions{
+		Ctx:    context.Background(),
+		Method: "GET",
+		Url:    "/qwe",
+		RetryPolicy: &RetryPolicy{
+			MaxAttempts:    5,
+			InitialBackoff: time.Millisecond,
+			MaxBackoff:     10 * time.Millisecond,
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, attempts)
+}
+
+func TestRetryOnDroppedConnection(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer ln.Close()
+
+	var attempts int32
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			atomic.AddInt32(&attempts, 1)
+			conn.Close()
+		}
+	}()
+
+	droppingClient := NewHttpClient(HttpClientParams{
+		BaseUrl: "http://" + ln.Addr().String(),
+		Timeout: time.Second,
+	})
+
+	_, err = droppingClient.DoRequestWithOptions(RequestOptions{
+		Ctx:    context.Background(),
+		Method: "GET",
+		Url:    "/qwe",
+		RetryPolicy: &RetryPolicy{
+			MaxAttempts:    3,
+			InitialBackoff: time.Millisecond,
+			MaxBackoff:     10 * time.Millisecond,
+		},
+	})
+	assert.Error(t, err)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&attempts))
+}
+
+func TestRetryPostOnDialFailure(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	addr := ln.Addr().String()
+	assert.NoError(t, ln.Close())
+
+	unreachableClient := NewHttpClient(HttpClientParams{
+		BaseUrl: "http://" + addr,
+		Timeout: time.Second,
+	})
+
+	// RetryPostOnConnectionErrors is deliberately left unset: a dial
+	// failure (connection refused, a net.Error retryable by default)
+	// never gets as far as writing the body, so the POST should still be
+	// retryable without it.
+	var retries int32
+	_, err = unreachableClient.DoRequestWithOptions(RequestOptions{
+		Ctx:     context.Background(),
+		Method:  "POST",
+		Url:     "/qwe",
+		Payload: map[string]string{"hello": "world"},
+		RetryPolicy: &RetryPolicy{
+			MaxAttempts:    3,
+			InitialBackoff: time.Millisecond,
+			MaxBackoff:     10 * time.Millisecond,
+			OnRetry: func(attempt int, err error, nextBackoff time.Duration) {
+				atomic.AddInt32(&retries, 1)
+			},
+		},
+	})
+	assert.Error(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&retries))
+}
+
+func TestReadDeadline(t *testing.T) {
+	deadlineClient := NewHttpClient(HttpClientParams{
+		BaseUrl: hs.URL,
+		Timeout: 2 * time.Second,
+	})
+
+	handler = func(rw http.ResponseWriter, req *http.Request) {
+		fl, _ := rw.(http.Flusher)
+		io.WriteString(rw, "a")
+		fl.Flush()
+		time.Sleep(300 * time.Millisecond)
+		io.WriteString(rw, "b")
+	}
+
+	resp, err := deadlineClient.DoRequestWithOptions(RequestOptions{
+		Ctx:          context.Background(),
+		Method:       "GET",
+		Url:          "/qwe",
+		ReadDeadline: time.Now().Add(100 * time.Millisecond),
+	})
+	assert.NoError(t, err)
+
+	_, err = io.ReadAll(resp.Body)
+	assert.True(t, errors.Is(err, DeadlineExceededError{}))
+}
+
+func TestNDJSONStream(t *testing.T) {
+	handler = func(rw http.ResponseWriter, req *http.Request) {
+		fl, _ := rw.(http.Flusher)
+		io.WriteString(rw, "{\"n\":1}\n")
+		fl.Flush()
+		io.WriteString(rw, "{\"n\":2}\n")
+		fl.Flush()
+	}
+
+	var items []interface{}
+	_, err := client.DoRequestWithOptions(RequestOptions{
+		Ctx:           context.Background(),
+		Method:        "GET",
+		Url:           "/qwe",
+		StreamDecoder: NDJSONStreamDecoder{},
+		OnItem: func(item interface{}) error {
+			items = append(items, item)
+			return nil
+		},
+	})
+	assert.NoError(t, err)
+	assert.Len(t, items, 2)
+}
+
+func TestSSEStream(t *testing.T) {
+	handler = func(rw http.ResponseWriter, req *http.Request) {
+		fl, _ := rw.(http.Flusher)
+		io.WriteString(rw, "event: ping\ndata: hello\n\n")
+		fl.Flush()
+		io.WriteString(rw, "data: line1\ndata: line2\n\n")
+		fl.Flush()
+	}
+
+	var events []SSEEvent
+	_, err := client.DoRequestWithOptions(RequestOptions{
+		Ctx:           context.Background(),
+		Method:        "GET",
+		Url:           "/qwe",
+		StreamDecoder: SSEStreamDecoder{},
+		OnItem: func(item interface{}) error {
+			events = append(events, item.(SSEEvent))
+			return nil
+		},
+	})
+	assert.NoError(t, err)
+	assert.Len(t, events, 2)
+	assert.Equal(t, "ping", events[0].Event)
+	assert.Equal(t, "hello", events[0].Data)
+	assert.Equal(t, "line1\nline2", events[1].Data)
+}
+
+func TestChunkedJSONArrayStream(t *testing.T) {
+	handler = func(rw http.ResponseWriter, req *http.Request) {
+		io.WriteString(rw, `[{"n":1},{"n":2},{"n":3}]`)
+	}
+
+	var items []interface{}
+	_, err := client.DoRequestWithOptions(RequestOptions{
+		Ctx:           context.Background(),
+		Method:        "GET",
+		Url:           "/qwe",
+		StreamDecoder: ChunkedJSONArrayDecoder{},
+		OnItem: func(item interface{}) error {
+			items = append(items, item)
+			return nil
+		},
+	})
+	assert.NoError(t, err)
+	assert.Len(t, items, 3)
+}
+
 func TestCtxCancel(t *testing.T) {
 	ctx, _ := context.WithTimeout(context.Background(), time.Millisecond)
 	_, err := client.DoRequestWithOptions(RequestOptions{
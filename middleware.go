@@ -0,0 +1,33 @@
+package http_client
+
+import (
+	"context"
+	"net/http"
+)
+
+// Endpoint represents a single request/response round trip through the
+// client, in the spirit of go-kit's transport Endpoint. It is the unit
+// that Middleware wraps.
+type Endpoint func(ctx context.Context, opts RequestOptions) (*http.Response, error)
+
+// Middleware wraps an Endpoint with additional behaviour (tracing, auth
+// token refresh, metrics, signing, ...) without needing access to
+// HttpClient internals.
+type Middleware func(Endpoint) Endpoint
+
+// RequestFunc is invoked with the outgoing *http.Request right after it
+// is built, before it is sent. It may return a modified context that is
+// carried forward to the ResponseFunc chain and the rest of the request
+// lifecycle.
+type RequestFunc func(ctx context.Context, req *http.Request) context.Context
+
+// ResponseFunc is invoked with the *http.Response right after it is
+// received, before error handling and result decoding.
+type ResponseFunc func(ctx context.Context, resp *http.Response) context.Context
+
+func chainMiddlewares(endpoint Endpoint, middlewares []Middleware) Endpoint {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		endpoint = middlewares[i](endpoint)
+	}
+	return endpoint
+}
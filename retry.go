@@ -0,0 +1,173 @@
+package http_client
+
+import (
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RetryPolicy configures automatic retries of DoRequestWithOptions on
+// transient failures. A nil RetryPolicy (the default) disables retries,
+// preserving the pre-existing single-attempt behavior.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first
+	// one. Values <= 1 disable retries.
+	MaxAttempts int
+	// InitialBackoff is the base delay before the first retry.
+	InitialBackoff time.Duration
+	// MaxBackoff caps the computed delay between retries.
+	MaxBackoff time.Duration
+	// Multiplier grows the backoff on each subsequent attempt. Defaults
+	// to 2 when left at zero.
+	Multiplier float64
+	// Jitter enables "full jitter": sleep = rand(0, min(MaxBackoff,
+	// InitialBackoff*Multiplier^attempt)).
+	Jitter bool
+	// RetryableStatusCodes lists response status codes that trigger a
+	// retry. Defaults to 429, 500, 502, 503 and 504 when left empty.
+	RetryableStatusCodes []int
+	// RetryableErrors lists sentinel errors that, in addition to network
+	// errors, should trigger a retry when matched via errors.Is.
+	RetryableErrors []error
+	// PerAttemptTimeout, when set, bounds each individual attempt with
+	// its own context.WithTimeout, independent of the overall ctx.
+	PerAttemptTimeout time.Duration
+	// RespectRetryAfter honors a Retry-After response header in place of
+	// the computed backoff.
+	RespectRetryAfter bool
+	// RetryPostOnConnectionErrors allows POST requests to be retried on
+	// connection-level errors even though the request body may already
+	// have been sent. Without it, POST is only retried when the failed
+	// attempt never got as far as writing its body onto the wire (e.g. a
+	// DNS or dial error), regardless of whether the request carried a
+	// payload.
+	RetryPostOnConnectionErrors bool
+	// OnRetry, when set, is called before sleeping ahead of each retry.
+	OnRetry func(attempt int, err error, nextBackoff time.Duration)
+}
+
+var defaultRetryableStatusCodes = []int{
+	http.StatusTooManyRequests,
+	http.StatusInternalServerError,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+func (p *RetryPolicy) maxAttempts() int {
+	if p == nil || p.MaxAttempts <= 0 {
+		return 1
+	}
+	return p.MaxAttempts
+}
+
+func (p *RetryPolicy) multiplier() float64 {
+	if p.Multiplier <= 0 {
+		return 2
+	}
+	return p.Multiplier
+}
+
+// backoff computes the delay ahead of the given retry attempt (0-indexed:
+// 0 is the delay before the second overall attempt), applying full jitter
+// when Jitter is set.
+func (p *RetryPolicy) backoff(attempt int) time.Duration {
+	if p == nil {
+		return 0
+	}
+	max := float64(p.MaxBackoff)
+	cur := float64(p.InitialBackoff)
+	for i := 0; i < attempt; i++ {
+		cur *= p.multiplier()
+		if max > 0 && cur > max {
+			cur = max
+			break
+		}
+	}
+	if max > 0 && cur > max {
+		cur = max
+	}
+	if !p.Jitter {
+		return time.Duration(cur)
+	}
+	if cur <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(cur) + 1))
+}
+
+func (p *RetryPolicy) isRetryableStatus(statusCode int) bool {
+	codes := p.RetryableStatusCodes
+	if len(codes) == 0 {
+		codes = defaultRetryableStatusCodes
+	}
+	for _, c := range codes {
+		if c == statusCode {
+			return true
+		}
+	}
+	return false
+}
+
+// isRetryableError reports whether the error a failed round trip returned
+// should trigger a retry. err must be the raw error the failed operation returned, not one
+// wrapped via wrapErr.Wrap: WrapErr.Is only string-compares its own
+// curErr, it does not delegate into a wrapped stdlib error's own Unwrap
+// chain, so errors.Is(wrapped, io.EOF) would miss a *url.Error wrapping
+// io.EOF. Any net.Error (dial/connect failures, connection reset,
+// timeouts, ...) is retryable by default, on top of io.EOF and
+// RetryableErrors.
+func (p *RetryPolicy) isRetryableError(err error) bool {
+	if errors.Is(err, io.EOF) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	for _, target := range p.RetryableErrors {
+		if target != nil && errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// methodIsRetryable reports whether method is allowed to be retried given
+// the policy and whether the request body had already started going out
+// on the wire when the attempt failed.
+func (p *RetryPolicy) methodIsRetryable(method string, bodySent bool) bool {
+	switch strings.ToUpper(method) {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete:
+		return true
+	case http.MethodPost:
+		return p.RetryPostOnConnectionErrors || !bodySent
+	default:
+		return false
+	}
+}
+
+// retryAfterDelay parses a Retry-After header (seconds or HTTP-date) and
+// reports whether one was present.
+func retryAfterDelay(resp *http.Response) (time.Duration, bool) {
+	v := resp.Header.Get("Retry-After")
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		return time.Duration(secs) * time.Second, true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		d := time.Until(t)
+		if d < 0 {
+			d = 0
+		}
+		return d, true
+	}
+	return 0, false
+}